@@ -0,0 +1,47 @@
+package expo
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"net/http"
+)
+
+// CompressionThreshold is the request body size above which publishInternal
+// gzips the request body even if ClientConfig.Compress isn't set
+const CompressionThreshold = 1024
+
+// gzipBody gzips jsonBytes when forceCompress is set or the payload exceeds
+// CompressionThreshold, returning the body to send and whether it was
+// compressed.
+func gzipBody(jsonBytes []byte, forceCompress bool) (io.Reader, bool, error) {
+	if !forceCompress && len(jsonBytes) <= CompressionThreshold {
+		return bytes.NewReader(jsonBytes), false, nil
+	}
+
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	if _, err := gz.Write(jsonBytes); err != nil {
+		return nil, false, err
+	}
+	if err := gz.Close(); err != nil {
+		return nil, false, err
+	}
+	return bytes.NewReader(buf.Bytes()), true, nil
+}
+
+// decodeJSONResponse JSON-decodes resp's body into v, transparently
+// gzip-decompressing it first if Expo sent Content-Encoding: gzip.
+func decodeJSONResponse(resp *http.Response, v interface{}) error {
+	if resp.Header.Get("Content-Encoding") != "gzip" {
+		return json.NewDecoder(resp.Body).Decode(v)
+	}
+
+	gz, err := gzip.NewReader(resp.Body)
+	if err != nil {
+		return err
+	}
+	defer gz.Close()
+	return json.NewDecoder(gz).Decode(v)
+}
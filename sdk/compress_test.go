@@ -0,0 +1,62 @@
+package expo
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"testing"
+)
+
+func TestGzipBodyBelowThreshold(t *testing.T) {
+	_, compressed, err := gzipBody([]byte(`{"a":1}`), false)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if compressed {
+		t.Error("expected small payload to not be compressed")
+	}
+}
+
+func TestGzipBodyForced(t *testing.T) {
+	body, compressed, err := gzipBody([]byte(`{"a":1}`), true)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if !compressed {
+		t.Error("expected Compress to force compression")
+	}
+	gz, err := gzip.NewReader(body)
+	if err != nil {
+		t.Fatalf("expected a valid gzip stream: %s", err)
+	}
+	defer gz.Close()
+	decoded, err := io.ReadAll(gz)
+	if err != nil {
+		t.Fatalf("unexpected error reading gzip stream: %s", err)
+	}
+	if string(decoded) != `{"a":1}` {
+		t.Errorf("unexpected decompressed body: %s", decoded)
+	}
+}
+
+func TestDecodeJSONResponseGzipped(t *testing.T) {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	gz.Write([]byte(`{"status":"ok"}`))
+	gz.Close()
+
+	resp := &http.Response{
+		Header: http.Header{"Content-Encoding": []string{"gzip"}},
+		Body:   io.NopCloser(&buf),
+	}
+	var decoded struct {
+		Status string `json:"status"`
+	}
+	if err := decodeJSONResponse(resp, &decoded); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if decoded.Status != "ok" {
+		t.Errorf("expected status ok, got %s", decoded.Status)
+	}
+}
@@ -1,7 +1,9 @@
 package expo
 
 import (
+	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
 	"strings"
 )
@@ -29,13 +31,25 @@ const (
 	HighPriority = "high"
 )
 
+// RichContent holds the rich media attached to a PushMessage, displayed
+// alongside the notification on iOS 10+ and Android.
+type RichContent struct {
+	// Image is a URL to an image to display with the notification.
+	Image string `json:"image,omitempty"`
+}
+
 // PushMessage is an object that describes a push notification request.
 // Fields:
-//	To: an ExponentPushToken
-//	Data: A dict of extra data to pass inside of the push notification.
-//	      The total notification payload must be at most 4096 bytes.
+//	To: one or more ExponentPushTokens to deliver this message to. Expo
+//	    fans the request out server-side, so batching many recipients onto
+//	    a single PushMessage is cheaper than sending one PushMessage per
+//	    recipient.
+//	Data: Extra data to pass inside of the push notification.
+//	      The total notification payload must be at most MaxMessageBytes.
 //	Title: The title to display in the notification. On iOS, this is
 //	       displayed only on Apple Watch.
+//	Subtitle: The subtitle to display in the notification below the title.
+//	          iOS only.
 //	Body: The message to display in the notification.
 //	Sound: A sound to play when the recipient receives this
 //	       notification. Specify "default" to play the device's default
@@ -51,17 +65,64 @@ const (
 //	       currently only affects iOS. Specify 0 to clear the badge count.
 //	ChannelID: ID of the Notification Channel through which to display this
 //         notification on Android devices.
+//	CategoryID: ID of the iOS notification category this notification is
+//	            associated with, used to attach interactive actions.
+//	MutableContent: Whether this notification can be intercepted by the
+//	                client app's iOS notification service app extension.
+//	ContentAvailable: Whether this notification should trigger a background
+//	                  app refresh on iOS, delivered silently without an
+//	                  alert, sound, or badge.
+//	DisplayInForeground: Whether to display the notification when the app
+//	                     is in the foreground. Deprecated in favor of
+//	                     interaction with notification handlers client-side.
+//	InterruptionLevel: The iOS 15+ interruption level to request, e.g.
+//	                   "passive", "active", "time-sensitive", or "critical".
+//	RichContent: Rich media, such as an image, to attach to the notification.
 type PushMessage struct {
-	To         ExponentPushToken `json:"to"`
-	Body       string            `json:"body"`
-	Data       map[string]string `json:"data,omitempty"`
-	Sound      string            `json:"sound,omitempty"`
-	Title      string            `json:"title,omitempty"`
-	TTLSeconds int               `json:"ttl,omitempty"`
-	Expiration int64             `json:"expiration,omitempty"`
-	Priority   string            `json:"priority,omitempty"`
-	Badge      int               `json:"badge,omitempty"`
-	ChannelID  string            `json:"channelId,omitempty"`
+	To                  []ExponentPushToken    `json:"to"`
+	Body                string                 `json:"body"`
+	Data                map[string]interface{} `json:"data,omitempty"`
+	Sound               string                 `json:"sound,omitempty"`
+	Title               string                 `json:"title,omitempty"`
+	Subtitle            string                 `json:"subtitle,omitempty"`
+	TTLSeconds          int                    `json:"ttl,omitempty"`
+	Expiration          int64                  `json:"expiration,omitempty"`
+	Priority            string                 `json:"priority,omitempty"`
+	Badge               int                    `json:"badge,omitempty"`
+	ChannelID           string                 `json:"channelId,omitempty"`
+	CategoryID          string                 `json:"categoryId,omitempty"`
+	MutableContent      bool                   `json:"mutableContent,omitempty"`
+	ContentAvailable    bool                   `json:"_contentAvailable,omitempty"`
+	DisplayInForeground bool                   `json:"_displayInForeground,omitempty"`
+	InterruptionLevel   string                 `json:"interruptionLevel,omitempty"`
+	RichContent         *RichContent           `json:"richContent,omitempty"`
+}
+
+// MaxMessageBytes is the maximum size, in bytes, of a single JSON-encoded
+// PushMessage that Expo will accept
+const MaxMessageBytes = 4096
+
+// Validate returns a MessageTooBigError if the JSON-encoded message exceeds
+// MaxMessageBytes, so that oversized messages can be rejected client-side
+// before hitting the network.
+func (m *PushMessage) Validate() error {
+	encoded, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+	if len(encoded) <= MaxMessageBytes {
+		return nil
+	}
+	return &MessageTooBigError{
+		PushResponseError: PushResponseError{
+			Response: &PushResponse{
+				PushMessage: *m,
+				Status:      "error",
+				Message:     fmt.Sprintf("Message too big: %d bytes, max is %d bytes", len(encoded), MaxMessageBytes),
+				Details:     map[string]string{"error": ErrorMessageTooBig},
+			},
+		},
+	}
 }
 
 // Response is the HTTP response returned from an Expo publish HTTP request
@@ -82,6 +143,14 @@ const ErrorMessageTooBig = "MessageTooBig"
 // ErrorMessageRateExceeded indicates messages have been sent too frequently
 const ErrorMessageRateExceeded = "MessageRateExceeded"
 
+// ErrorMismatchSenderID indicates the token was registered with a different
+// FCM/GCM sender ID than the one configured for this project
+const ErrorMismatchSenderID = "MismatchSenderId"
+
+// ErrorInvalidCredentials indicates the push notification credentials for
+// this app are invalid or have been revoked
+const ErrorInvalidCredentials = "InvalidCredentials"
+
 // PushResponse is a wrapper class for a push notification response.
 // A successful single push notification:
 //     {'status': 'ok'}
@@ -93,6 +162,28 @@ type PushResponse struct {
 	Status      string            `json:"status"`
 	Message     string            `json:"message"`
 	Details     map[string]string `json:"details"`
+	ID          string            `json:"id"`
+}
+
+// PushTicket is the immediate per-message result returned by POST
+// /push/send. Expo hasn't attempted delivery yet at this point, so a
+// successful ticket only means the request was accepted; use its ID with
+// PushClient.GetReceipts to retrieve the final delivery PushReceipt.
+type PushTicket = PushResponse
+
+// PushReceipt is the final delivery status for a single push notification,
+// fetched via PushClient.GetReceipts using the ID from a PushTicket.
+type PushReceipt struct {
+	Status  string            `json:"status"`
+	Message string            `json:"message"`
+	Details map[string]string `json:"details"`
+}
+
+// ValidateResponse returns an error if the receipt indicates that one
+// occurred, reusing the same error mapping as PushResponse.ValidateResponse.
+func (r *PushReceipt) ValidateResponse() error {
+	response := &PushResponse{Status: r.Status, Message: r.Message, Details: r.Details}
+	return response.ValidateResponse()
 }
 
 func (r *PushResponse) isSuccess() bool {
@@ -124,6 +215,14 @@ func (r *PushResponse) ValidateResponse() error {
 			return &MessageRateExceededError{
 				PushResponseError: *err,
 			}
+		} else if e == ErrorMismatchSenderID {
+			return &MismatchSenderIDError{
+				PushResponseError: *err,
+			}
+		} else if e == ErrorInvalidCredentials {
+			return &InvalidCredentialsError{
+				PushResponseError: *err,
+			}
 		}
 	}
 	return err
@@ -159,6 +258,37 @@ type MessageRateExceededError struct {
 	PushResponseError
 }
 
+// MismatchSenderIDError is raised when the push token was registered with a
+// different FCM/GCM sender ID than the one configured for this project.
+// To handle this error, use the sender ID tied to the token, or have the
+// client re-register for a token using this project's sender ID.
+type MismatchSenderIDError struct {
+	PushResponseError
+}
+
+// InvalidCredentialsError is raised when the push notification credentials
+// for this app are invalid or have been revoked, e.g. a rotated FCM server
+// key or a revoked APNs certificate.
+type InvalidCredentialsError struct {
+	PushResponseError
+}
+
+// ErrorTooManyExperienceIds indicates a single request targeted more Expo
+// experience IDs than Expo allows
+const ErrorTooManyExperienceIds = "PUSH_TOO_MANY_EXPERIENCE_IDS"
+
+// ErrorTooManyNotifications indicates a single request contained more
+// notifications than Expo allows
+const ErrorTooManyNotifications = "PUSH_TOO_MANY_NOTIFICATIONS"
+
+// ErrorTooManyReceipts indicates a single getReceipts request asked for more
+// receipts than Expo allows
+const ErrorTooManyReceipts = "PUSH_TOO_MANY_RECEIPTS"
+
+// ErrorTooManyRequests indicates the request was rejected by Expo's rate
+// limiter (HTTP 429)
+const ErrorTooManyRequests = "TOO_MANY_REQUESTS"
+
 // PushServerError is raised when the push token server is not behaving as expected
 // For example, invalid push notification arguments result in a different
 // style of error. Instead of a "data" array containing errors per
@@ -173,17 +303,27 @@ type PushServerError struct {
 	Response     *http.Response
 	ResponseData *Response
 	Errors       []map[string]string
+	// ErrorCode is the `code` of the first entry in Errors, e.g.
+	// ErrorTooManyRequests or ErrorTooManyNotifications, letting callers
+	// distinguish rate/quota errors from schema errors without string
+	// matching on Message. Empty if Errors carried no code.
+	ErrorCode string
 }
 
 // NewPushServerError creates a new PushServerError object
 func NewPushServerError(message string, response *http.Response,
 	responseData *Response,
 	errors []map[string]string) *PushServerError {
+	errorCode := ""
+	if len(errors) > 0 {
+		errorCode = errors[0]["code"]
+	}
 	return &PushServerError{
 		Message:      message,
 		Response:     response,
 		ResponseData: responseData,
 		Errors:       errors,
+		ErrorCode:    errorCode,
 	}
 }
 
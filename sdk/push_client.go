@@ -1,11 +1,12 @@
 package expo
 
 import (
-	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"net/http"
+	"time"
 )
 
 const (
@@ -13,6 +14,16 @@ const (
 	DefaultHost = "https://exp.host"
 	// DefaultBaseAPIURL is the default path for API requests
 	DefaultBaseAPIURL = "/--/api/v2"
+	// ReceiptsIDLimit is the maximum number of ticket IDs Expo accepts in a
+	// single /push/getReceipts request
+	ReceiptsIDLimit = 1000
+	// MaxBatchSize is the maximum number of PushMessage entries Expo
+	// accepts in a single /push/send request
+	MaxBatchSize = 100
+	// DefaultReceiptWaitInterval is how long PublishAndAwaitReceipts waits
+	// before querying receipts, matching Expo's guidance that receipts
+	// aren't available until roughly 15 minutes after a ticket is issued
+	DefaultReceiptWaitInterval = 15 * time.Minute
 )
 
 // DefaultHTTPClient is the default *http.Client for making API requests
@@ -25,6 +36,8 @@ type PushClient struct {
 	accessToken string
 	httpClient  *http.Client
 	url         string
+	maxRetries  int
+	compress    bool
 }
 
 // ClientConfig specifies params that can optionally be specified for alternate
@@ -35,6 +48,13 @@ type ClientConfig struct {
 	AccessToken string
 	HTTPClient  *http.Client
 	URL         string
+	// MaxRetries is the number of times a request is retried on a 429 or
+	// 5xx response. Defaults to DefaultMaxRetries.
+	MaxRetries int
+	// Compress forces gzip-compressed request bodies regardless of payload
+	// size. Requests already larger than CompressionThreshold are
+	// compressed automatically either way.
+	Compress bool
 }
 
 // NewPushClient creates a new Exponent push client
@@ -46,6 +66,8 @@ func NewPushClient(config *ClientConfig) *PushClient {
 	httpClient := DefaultHTTPClient
 	accessToken := ""
 	url := ""
+	maxRetries := DefaultMaxRetries
+	compress := false
 
 	if config != nil {
 		if config.Host != "" {
@@ -63,21 +85,43 @@ func NewPushClient(config *ClientConfig) *PushClient {
 		if config.URL != "" {
 			url = config.URL
 		}
+		if config.MaxRetries != 0 {
+			maxRetries = config.MaxRetries
+		}
+		compress = config.Compress
 	}
 	c.host = host
 	c.apiURL = apiURL
 	c.httpClient = httpClient
 	c.accessToken = accessToken
 	c.url = url
+	c.maxRetries = maxRetries
+	c.compress = compress
 	return c
 }
 
-// Publish sends a single push notification
-// @param push_message: A PushMessage object
-// @return an array of PushResponse objects which contains the results.
-// @return error if any requests failed
+// Publish sends a single push notification to a single recipient. Messages
+// with more than one recipient on To must go through PublishMultiple, since
+// Publish's single PushResponse return value has no way to report more than
+// one result.
+// @param push_message: A PushMessage object with exactly one recipient
+// @return a PushResponse with the result
+// @return error if the request failed, or if message.To has more than one recipient
 func (c *PushClient) Publish(message *PushMessage) (PushResponse, error) {
-	responses, err := c.PublishMultiple([]PushMessage{*message})
+	return c.PublishWithContext(context.Background(), message)
+}
+
+// PublishWithContext is Publish with a context.Context used to cancel the
+// request or set a deadline.
+// @param ctx: a context.Context used to cancel the request or set a deadline
+// @param push_message: A PushMessage object with exactly one recipient
+// @return a PushResponse with the result
+// @return error if the request failed, or if message.To has more than one recipient
+func (c *PushClient) PublishWithContext(ctx context.Context, message *PushMessage) (PushResponse, error) {
+	if len(message.To) > 1 {
+		return PushResponse{}, errors.New("Publish only supports a single recipient; use PublishMultiple for a PushMessage with more than one recipient in To")
+	}
+	responses, err := c.PublishMultipleWithContext(ctx, []PushMessage{*message})
 	if err != nil {
 		return PushResponse{}, err
 	}
@@ -89,10 +133,184 @@ func (c *PushClient) Publish(message *PushMessage) (PushResponse, error) {
 // @return an array of PushResponse objects which contains the results.
 // @return error if the request failed
 func (c *PushClient) PublishMultiple(messages []PushMessage) ([]PushResponse, error) {
-	return c.publishInternal(messages)
+	return c.PublishMultipleWithContext(context.Background(), messages)
+}
+
+// PublishMultipleWithContext sends multiple push notifications at once,
+// aborting early if ctx is cancelled before the request completes.
+// @param ctx: a context.Context used to cancel the request or set a deadline
+// @param push_messages: An array of PushMessage objects.
+// @return an array of PushResponse objects which contains the results.
+// @return error if the request failed
+func (c *PushClient) PublishMultipleWithContext(ctx context.Context, messages []PushMessage) ([]PushResponse, error) {
+	return c.publishInternal(ctx, messages)
+}
+
+// PublishMultipleWithRetry sends messages like PublishMultiple, then
+// re-sends just the messages that came back with a MessageRateExceededError,
+// using the same exponential backoff schedule as transport-level retries.
+// @param messages: An array of PushMessage objects.
+// @return an array of PushResponse objects in the same order as messages
+// @return error if the request failed
+func (c *PushClient) PublishMultipleWithRetry(messages []PushMessage) ([]PushResponse, error) {
+	return c.PublishMultipleWithRetryContext(context.Background(), messages)
+}
+
+// PublishMultipleWithRetryContext is PublishMultipleWithRetry with a
+// context.Context; the backoff sleeps between retries abort early if ctx is
+// cancelled.
+func (c *PushClient) PublishMultipleWithRetryContext(ctx context.Context, messages []PushMessage) ([]PushResponse, error) {
+	responses, err := c.PublishMultipleWithContext(ctx, messages)
+	if err != nil {
+		return nil, err
+	}
+
+	for attempt := 0; attempt < c.maxRetries; attempt++ {
+		var retryIndexes []int
+		var retryMessages []PushMessage
+		for i, response := range responses {
+			if _, ok := response.ValidateResponse().(*MessageRateExceededError); ok {
+				retryIndexes = append(retryIndexes, i)
+				retryMessages = append(retryMessages, response.PushMessage)
+			}
+		}
+		if len(retryMessages) == 0 {
+			break
+		}
+
+		if err := sleepContext(ctx, backoffWithJitter(attempt)); err != nil {
+			return nil, err
+		}
+
+		retryResponses, err := c.PublishMultipleWithContext(ctx, retryMessages)
+		if err != nil {
+			return nil, err
+		}
+		for j, idx := range retryIndexes {
+			responses[idx] = retryResponses[j]
+		}
+	}
+	return responses, nil
+}
+
+// GetReceipts fetches the delivery receipts for the given PushTicket IDs.
+// @param ticketIDs: the IDs returned on the PushTicket objects from Publish/PublishMultiple
+// @return a map of ticket ID to its PushReceipt
+// @return error if the request failed
+func (c *PushClient) GetReceipts(ticketIDs []string) (map[string]PushReceipt, error) {
+	return c.GetReceiptsWithContext(context.Background(), ticketIDs)
+}
+
+// GetReceiptsWithContext is GetReceipts with a context.Context used to cancel
+// the request or set a deadline.
+func (c *PushClient) GetReceiptsWithContext(ctx context.Context, ticketIDs []string) (map[string]PushReceipt, error) {
+	receipts := make(map[string]PushReceipt, len(ticketIDs))
+	for start := 0; start < len(ticketIDs); start += ReceiptsIDLimit {
+		end := start + ReceiptsIDLimit
+		if end > len(ticketIDs) {
+			end = len(ticketIDs)
+		}
+		chunk, err := c.getReceiptsInternal(ctx, ticketIDs[start:end])
+		if err != nil {
+			return nil, err
+		}
+		for id, receipt := range chunk {
+			receipts[id] = receipt
+		}
+	}
+	return receipts, nil
+}
+
+func (c *PushClient) getReceiptsInternal(ctx context.Context, ticketIDs []string) (map[string]PushReceipt, error) {
+	url := fmt.Sprintf("%s%s/push/getReceipts", c.host, c.apiURL)
+
+	jsonBytes, err := json.Marshal(struct {
+		IDs []string `json:"ids"`
+	}{IDs: ticketIDs})
+	if err != nil {
+		return nil, err
+	}
+
+	body, compressed, err := gzipBody(jsonBytes, c.compress)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", url, body)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Add("Content-Type", "application/json")
+	req.Header.Add("Accept-Encoding", "gzip")
+	if compressed {
+		req.Header.Add("Content-Encoding", "gzip")
+	}
+	if c.accessToken != "" {
+		req.Header.Add("Authorization", "Bearer "+c.accessToken)
+	}
+
+	resp, err := c.doWithRetry(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	err = checkStatus(resp)
+	if err != nil {
+		return nil, err
+	}
+
+	var r struct {
+		Data   map[string]PushReceipt `json:"data"`
+		Errors []map[string]string    `json:"errors"`
+	}
+	err = decodeJSONResponse(resp, &r)
+	if err != nil {
+		return nil, err
+	}
+	if r.Errors != nil {
+		return nil, NewPushServerError("Invalid server response", resp, nil, r.Errors)
+	}
+	return r.Data, nil
+}
+
+// PublishAndAwaitReceipts sends the given messages, waits waitInterval for
+// Expo to attempt delivery, then fetches and returns the resulting receipts
+// keyed by ticket ID. Pass 0 to use DefaultReceiptWaitInterval.
+// @param messages: An array of PushMessage objects.
+// @param waitInterval: how long to wait before querying receipts
+// @return a map of ticket ID to its PushReceipt
+// @return error if sending or fetching receipts failed
+func (c *PushClient) PublishAndAwaitReceipts(messages []PushMessage, waitInterval time.Duration) (map[string]PushReceipt, error) {
+	return c.PublishAndAwaitReceiptsContext(context.Background(), messages, waitInterval)
 }
 
-func (c *PushClient) publishInternal(messages []PushMessage) ([]PushResponse, error) {
+// PublishAndAwaitReceiptsContext is PublishAndAwaitReceipts with a
+// context.Context; cancelling ctx aborts the wait before receipts are
+// fetched.
+func (c *PushClient) PublishAndAwaitReceiptsContext(ctx context.Context, messages []PushMessage, waitInterval time.Duration) (map[string]PushReceipt, error) {
+	tickets, err := c.PublishMultipleWithContext(ctx, messages)
+	if err != nil {
+		return nil, err
+	}
+
+	if waitInterval <= 0 {
+		waitInterval = DefaultReceiptWaitInterval
+	}
+	if err := sleepContext(ctx, waitInterval); err != nil {
+		return nil, err
+	}
+
+	ticketIDs := make([]string, 0, len(tickets))
+	for _, ticket := range tickets {
+		if ticket.ID != "" {
+			ticketIDs = append(ticketIDs, ticket.ID)
+		}
+	}
+	return c.GetReceiptsWithContext(ctx, ticketIDs)
+}
+
+func (c *PushClient) publishInternal(ctx context.Context, messages []PushMessage) ([]PushResponse, error) {
 	// Validate the messages
 	for _, message := range messages {
 		if len(message.To) == 0 {
@@ -103,8 +321,29 @@ func (c *PushClient) publishInternal(messages []PushMessage) ([]PushResponse, er
 				return nil, errors.New("Invalid push token")
 			}
 		}
+		if err := message.Validate(); err != nil {
+			return nil, err
+		}
+	}
+
+	// Expo rejects requests with more than MaxBatchSize messages, so chunk
+	// and stitch the responses back together in the original order.
+	responses := make([]PushResponse, 0, len(messages))
+	for start := 0; start < len(messages); start += MaxBatchSize {
+		end := start + MaxBatchSize
+		if end > len(messages) {
+			end = len(messages)
+		}
+		batchResponses, err := c.sendBatch(ctx, messages[start:end])
+		if err != nil {
+			return nil, err
+		}
+		responses = append(responses, batchResponses...)
 	}
+	return responses, nil
+}
 
+func (c *PushClient) sendBatch(ctx context.Context, messages []PushMessage) ([]PushResponse, error) {
 	url := fmt.Sprintf("%s%s/push/send", c.host, c.apiURL)
 	if c.url != "" {
 		url = c.url
@@ -115,20 +354,29 @@ func (c *PushClient) publishInternal(messages []PushMessage) ([]PushResponse, er
 		return nil, err
 	}
 
+	body, compressed, err := gzipBody(jsonBytes, c.compress)
+	if err != nil {
+		return nil, err
+	}
+
 	// Create request w/ body
-	req, err := http.NewRequest("POST", url, bytes.NewReader(jsonBytes))
+	req, err := http.NewRequestWithContext(ctx, "POST", url, body)
 	if err != nil {
 		return nil, err
 	}
 
 	// Add appropriate headers
 	req.Header.Add("Content-Type", "application/json")
+	req.Header.Add("Accept-Encoding", "gzip")
+	if compressed {
+		req.Header.Add("Content-Encoding", "gzip")
+	}
 	if c.accessToken != "" {
 		req.Header.Add("Authorization", "Bearer "+c.accessToken)
 	}
 
-	// Send request
-	resp, err := c.httpClient.Do(req)
+	// Send request, retrying on 429/5xx responses
+	resp, err := c.doWithRetry(ctx, req)
 	if err != nil {
 		return nil, err
 	}
@@ -141,7 +389,7 @@ func (c *PushClient) publishInternal(messages []PushMessage) ([]PushResponse, er
 
 	// Validate the response format first
 	var r *Response
-	err = json.NewDecoder(resp.Body).Decode(&r)
+	err = decodeJSONResponse(resp, &r)
 	if err != nil {
 		// The response isn't json
 		return nil, err
@@ -154,26 +402,71 @@ func (c *PushClient) publishInternal(messages []PushMessage) ([]PushResponse, er
 	if r.Data == nil {
 		return nil, NewPushServerError("Invalid server response", resp, r, nil)
 	}
-	// Sanity check the response
-	if len(messages) != len(r.Data) {
+	// Expo returns one response per recipient, not per message, since a
+	// single message's "to" field can fan out to many recipients.
+	expected := 0
+	for _, message := range messages {
+		expected += len(message.To)
+	}
+	if expected != len(r.Data) {
 		message := "Mismatched response length. Expected %d receipts but only received %d"
-		errorMessage := fmt.Sprintf(message, len(messages), len(r.Data))
+		errorMessage := fmt.Sprintf(message, expected, len(r.Data))
 		return nil, NewPushServerError(errorMessage, resp, r, nil)
 	}
-	// Add the original message to each response for reference
-	for i := range r.Data {
-		r.Data[i].PushMessage = messages[i]
+	// Add the original message to each response for reference. Each
+	// response corresponds to exactly one recipient, so scope the attached
+	// message to that single recipient rather than the whole fan-out list
+	// — PublishMultipleWithRetry relies on this to resend only the
+	// recipient that actually needs it.
+	i := 0
+	for _, message := range messages {
+		for _, recipient := range message.To {
+			single := message
+			single.To = []ExponentPushToken{recipient}
+			r.Data[i].PushMessage = single
+			i++
+		}
 	}
 	return r.Data, nil
 }
 
+// doWithRetry sends req, retrying on 429/5xx responses up to c.maxRetries
+// times. It honours Retry-After when present and otherwise backs off
+// exponentially with jitter between attempts. The wait between attempts is
+// interruptible via ctx.
+func (c *PushClient) doWithRetry(ctx context.Context, req *http.Request) (*http.Response, error) {
+	for attempt := 0; ; attempt++ {
+		if attempt > 0 && req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			req.Body = body
+		}
+
+		resp, err := c.httpClient.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		if !isRetryableStatus(resp.StatusCode) || attempt >= c.maxRetries {
+			return resp, nil
+		}
+
+		wait := retryDelay(resp, attempt)
+		resp.Body.Close()
+		if err := sleepContext(ctx, wait); err != nil {
+			return nil, err
+		}
+	}
+}
+
 func checkStatus(resp *http.Response) error {
 	if resp.StatusCode >= 200 && resp.StatusCode <= 299 {
 		return nil
 	}
 
 	if resp.StatusCode == 401 || resp.StatusCode == 403 {
-		return fmt.Errorf("invalid access token %s", resp.StatusCode)
+		return fmt.Errorf("invalid access token %d", resp.StatusCode)
 	}
 
 	return fmt.Errorf("invalid response %s", resp.Status)
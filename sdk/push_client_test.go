@@ -0,0 +1,341 @@
+package expo
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func decodeReceiptIDs(t *testing.T, r *http.Request) []string {
+	t.Helper()
+	reqBody := io.Reader(r.Body)
+	if r.Header.Get("Content-Encoding") == "gzip" {
+		gz, err := gzip.NewReader(r.Body)
+		if err != nil {
+			t.Fatalf("failed to read gzipped request body: %s", err)
+		}
+		defer gz.Close()
+		reqBody = gz
+	}
+	var body struct {
+		IDs []string `json:"ids"`
+	}
+	if err := json.NewDecoder(reqBody).Decode(&body); err != nil {
+		t.Fatalf("failed to decode request body: %s", err)
+	}
+	return body.IDs
+}
+
+func decodeMessages(t *testing.T, r *http.Request) []PushMessage {
+	t.Helper()
+	body := io.Reader(r.Body)
+	if r.Header.Get("Content-Encoding") == "gzip" {
+		gz, err := gzip.NewReader(r.Body)
+		if err != nil {
+			t.Fatalf("failed to read gzipped request body: %s", err)
+		}
+		defer gz.Close()
+		body = gz
+	}
+	var messages []PushMessage
+	if err := json.NewDecoder(body).Decode(&messages); err != nil {
+		t.Fatalf("failed to decode request body: %s", err)
+	}
+	return messages
+}
+
+func TestPublishMultipleBatchesOver100(t *testing.T) {
+	var mu sync.Mutex
+	var batchSizes []int
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		messages := decodeMessages(t, r)
+
+		mu.Lock()
+		batchSizes = append(batchSizes, len(messages))
+		mu.Unlock()
+
+		data := make([]PushResponse, 0, len(messages))
+		for _, message := range messages {
+			for range message.To {
+				data = append(data, PushResponse{Status: SuccessStatus})
+			}
+		}
+		json.NewEncoder(w).Encode(Response{Data: data})
+	}))
+	defer server.Close()
+
+	client := NewPushClient(&ClientConfig{URL: server.URL})
+
+	messages := make([]PushMessage, 250)
+	for i := range messages {
+		token := ExponentPushToken(fmt.Sprintf("ExponentPushToken[%d]", i))
+		messages[i] = PushMessage{To: []ExponentPushToken{token}, Body: "hi"}
+	}
+
+	responses, err := client.PublishMultiple(messages)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(responses) != 250 {
+		t.Fatalf("expected 250 responses, got %d", len(responses))
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(batchSizes) != 3 || batchSizes[0] != 100 || batchSizes[1] != 100 || batchSizes[2] != 50 {
+		t.Errorf("expected batches of 100, 100, 50; got %v", batchSizes)
+	}
+}
+
+func TestPublishMultipleRecipientStitching(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		messages := decodeMessages(t, r)
+
+		data := make([]PushResponse, 0)
+		for _, message := range messages {
+			for range message.To {
+				data = append(data, PushResponse{Status: SuccessStatus})
+			}
+		}
+		json.NewEncoder(w).Encode(Response{Data: data})
+	}))
+	defer server.Close()
+
+	client := NewPushClient(&ClientConfig{URL: server.URL})
+
+	messages := []PushMessage{
+		{To: []ExponentPushToken{"ExponentPushToken[A]", "ExponentPushToken[B]"}, Body: "hi"},
+		{To: []ExponentPushToken{"ExponentPushToken[C]"}, Body: "hi"},
+	}
+
+	responses, err := client.PublishMultiple(messages)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(responses) != 3 {
+		t.Fatalf("expected 3 responses, got %d", len(responses))
+	}
+
+	want := []ExponentPushToken{"ExponentPushToken[A]", "ExponentPushToken[B]", "ExponentPushToken[C]"}
+	for i, response := range responses {
+		if len(response.PushMessage.To) != 1 || response.PushMessage.To[0] != want[i] {
+			t.Errorf("response %d: expected To=[%s], got %v", i, want[i], response.PushMessage.To)
+		}
+	}
+}
+
+func TestPublishMultipleWithRetryPartialRateLimit(t *testing.T) {
+	var calls int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		messages := decodeMessages(t, r)
+
+		if atomic.AddInt32(&calls, 1) == 1 {
+			json.NewEncoder(w).Encode(Response{Data: []PushResponse{
+				{Status: SuccessStatus},
+				{Status: "error", Message: "rate limited", Details: map[string]string{"error": ErrorMessageRateExceeded}},
+			}})
+			return
+		}
+
+		// Only the rate-limited recipient should be resent, not the whole
+		// original message or its already-successful sibling.
+		if len(messages) != 1 {
+			t.Errorf("expected retry to resend exactly 1 message, got %d", len(messages))
+		}
+		json.NewEncoder(w).Encode(Response{Data: []PushResponse{{Status: SuccessStatus}}})
+	}))
+	defer server.Close()
+
+	client := NewPushClient(&ClientConfig{URL: server.URL, MaxRetries: 1})
+
+	messages := []PushMessage{
+		{To: []ExponentPushToken{"ExponentPushToken[A]"}, Body: "hi"},
+		{To: []ExponentPushToken{"ExponentPushToken[B]"}, Body: "hi"},
+	}
+
+	responses, err := client.PublishMultipleWithRetry(messages)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(responses) != 2 {
+		t.Fatalf("expected 2 responses, got %d", len(responses))
+	}
+	for i, response := range responses {
+		if response.Status != SuccessStatus {
+			t.Errorf("response %d: expected ok after retry, got %s", i, response.Status)
+		}
+	}
+	if got := atomic.LoadInt32(&calls); got != 2 {
+		t.Errorf("expected 2 HTTP calls (initial + retry), got %d", got)
+	}
+}
+
+func TestDoWithRetryHonoursRetryAfter(t *testing.T) {
+	var calls int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		decodeMessages(t, r)
+		json.NewEncoder(w).Encode(Response{Data: []PushResponse{{Status: SuccessStatus}}})
+	}))
+	defer server.Close()
+
+	client := NewPushClient(&ClientConfig{URL: server.URL, MaxRetries: 1})
+
+	response, err := client.Publish(&PushMessage{To: []ExponentPushToken{"ExponentPushToken[A]"}, Body: "hi"})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if response.Status != SuccessStatus {
+		t.Errorf("expected success after retry, got %s", response.Status)
+	}
+	if got := atomic.LoadInt32(&calls); got != 2 {
+		t.Errorf("expected 2 HTTP calls (initial 500 + retry), got %d", got)
+	}
+}
+
+func TestPublishRejectsMultipleRecipients(t *testing.T) {
+	client := NewPushClient(nil)
+	_, err := client.Publish(&PushMessage{
+		To:   []ExponentPushToken{"ExponentPushToken[A]", "ExponentPushToken[B]"},
+		Body: "hi",
+	})
+	if err == nil {
+		t.Error("expected Publish to reject a message with more than one recipient")
+	}
+}
+
+func TestGetReceiptsWithContext(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasSuffix(r.URL.Path, "/push/getReceipts") {
+			t.Errorf("expected request to /push/getReceipts, got %s", r.URL.Path)
+		}
+		ids := decodeReceiptIDs(t, r)
+
+		data := make(map[string]PushReceipt, len(ids))
+		for _, id := range ids {
+			data[id] = PushReceipt{Status: SuccessStatus}
+		}
+		json.NewEncoder(w).Encode(struct {
+			Data map[string]PushReceipt `json:"data"`
+		}{Data: data})
+	}))
+	defer server.Close()
+
+	client := NewPushClient(&ClientConfig{Host: server.URL})
+
+	receipts, err := client.GetReceiptsWithContext(context.Background(), []string{"ticket-a", "ticket-b"})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(receipts) != 2 {
+		t.Fatalf("expected 2 receipts, got %d", len(receipts))
+	}
+	if receipts["ticket-a"].Status != SuccessStatus || receipts["ticket-b"].Status != SuccessStatus {
+		t.Errorf("expected both receipts to be ok, got %+v", receipts)
+	}
+}
+
+func TestGetReceiptsChunksOver1000IDs(t *testing.T) {
+	var mu sync.Mutex
+	var batchSizes []int
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ids := decodeReceiptIDs(t, r)
+
+		mu.Lock()
+		batchSizes = append(batchSizes, len(ids))
+		mu.Unlock()
+
+		data := make(map[string]PushReceipt, len(ids))
+		for _, id := range ids {
+			data[id] = PushReceipt{Status: SuccessStatus}
+		}
+		json.NewEncoder(w).Encode(struct {
+			Data map[string]PushReceipt `json:"data"`
+		}{Data: data})
+	}))
+	defer server.Close()
+
+	client := NewPushClient(&ClientConfig{Host: server.URL})
+
+	ticketIDs := make([]string, 1500)
+	for i := range ticketIDs {
+		ticketIDs[i] = fmt.Sprintf("ticket-%d", i)
+	}
+
+	receipts, err := client.GetReceiptsWithContext(context.Background(), ticketIDs)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(receipts) != 1500 {
+		t.Fatalf("expected 1500 receipts, got %d", len(receipts))
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(batchSizes) != 2 || batchSizes[0] != 1000 || batchSizes[1] != 500 {
+		t.Errorf("expected batches of 1000, 500; got %v", batchSizes)
+	}
+}
+
+func TestPublishAndAwaitReceiptsContext(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/push/send"):
+			messages := decodeMessages(t, r)
+			data := make([]PushResponse, 0, len(messages))
+			for i, message := range messages {
+				for range message.To {
+					data = append(data, PushResponse{Status: SuccessStatus, ID: fmt.Sprintf("ticket-%d", i)})
+				}
+			}
+			json.NewEncoder(w).Encode(Response{Data: data})
+		case strings.HasSuffix(r.URL.Path, "/push/getReceipts"):
+			ids := decodeReceiptIDs(t, r)
+			data := make(map[string]PushReceipt, len(ids))
+			for _, id := range ids {
+				data[id] = PushReceipt{Status: SuccessStatus}
+			}
+			json.NewEncoder(w).Encode(struct {
+				Data map[string]PushReceipt `json:"data"`
+			}{Data: data})
+		default:
+			t.Errorf("unexpected request to %s", r.URL.Path)
+		}
+	}))
+	defer server.Close()
+
+	client := NewPushClient(&ClientConfig{URL: server.URL + "/push/send", Host: server.URL})
+
+	messages := []PushMessage{
+		{To: []ExponentPushToken{"ExponentPushToken[A]"}, Body: "hi"},
+		{To: []ExponentPushToken{"ExponentPushToken[B]"}, Body: "hi"},
+	}
+
+	receipts, err := client.PublishAndAwaitReceiptsContext(context.Background(), messages, time.Millisecond)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(receipts) != 2 {
+		t.Fatalf("expected 2 receipts, got %d", len(receipts))
+	}
+	if receipts["ticket-0"].Status != SuccessStatus || receipts["ticket-1"].Status != SuccessStatus {
+		t.Errorf("expected both receipts to be ok, got %+v", receipts)
+	}
+}
@@ -1,9 +1,108 @@
 package expo
 
 import (
+	"encoding/json"
+	"strings"
 	"testing"
 )
 
+func marshalMessage(t *testing.T, message PushMessage) string {
+	t.Helper()
+	data, err := json.Marshal(message)
+	if err != nil {
+		t.Fatalf("unexpected error marshalling PushMessage: %s", err)
+	}
+	return string(data)
+}
+
+func TestPushMessageJSONData(t *testing.T) {
+	message := PushMessage{
+		To:   []ExponentPushToken{"ExponentPushToken[test]"},
+		Data: map[string]interface{}{"count": 3, "nested": map[string]interface{}{"a": "b"}},
+	}
+	encoded := marshalMessage(t, message)
+	if !strings.Contains(encoded, `"count":3`) {
+		t.Errorf("expected arbitrary JSON data to be preserved, got %s", encoded)
+	}
+}
+
+func TestPushMessageJSONSubtitle(t *testing.T) {
+	message := PushMessage{To: []ExponentPushToken{"ExponentPushToken[test]"}, Subtitle: "A subtitle"}
+	encoded := marshalMessage(t, message)
+	if !strings.Contains(encoded, `"subtitle":"A subtitle"`) {
+		t.Errorf("expected subtitle field, got %s", encoded)
+	}
+}
+
+func TestPushMessageJSONMutableContent(t *testing.T) {
+	message := PushMessage{To: []ExponentPushToken{"ExponentPushToken[test]"}, MutableContent: true}
+	encoded := marshalMessage(t, message)
+	if !strings.Contains(encoded, `"mutableContent":true`) {
+		t.Errorf("expected mutableContent field, got %s", encoded)
+	}
+}
+
+func TestPushMessageJSONCategoryID(t *testing.T) {
+	message := PushMessage{To: []ExponentPushToken{"ExponentPushToken[test]"}, CategoryID: "message"}
+	encoded := marshalMessage(t, message)
+	if !strings.Contains(encoded, `"categoryId":"message"`) {
+		t.Errorf("expected categoryId field, got %s", encoded)
+	}
+}
+
+func TestPushMessageJSONContentAvailable(t *testing.T) {
+	message := PushMessage{To: []ExponentPushToken{"ExponentPushToken[test]"}, ContentAvailable: true}
+	encoded := marshalMessage(t, message)
+	if !strings.Contains(encoded, `"_contentAvailable":true`) {
+		t.Errorf("expected _contentAvailable field, got %s", encoded)
+	}
+}
+
+func TestPushMessageJSONDisplayInForeground(t *testing.T) {
+	message := PushMessage{To: []ExponentPushToken{"ExponentPushToken[test]"}, DisplayInForeground: true}
+	encoded := marshalMessage(t, message)
+	if !strings.Contains(encoded, `"_displayInForeground":true`) {
+		t.Errorf("expected _displayInForeground field, got %s", encoded)
+	}
+}
+
+func TestPushMessageJSONInterruptionLevel(t *testing.T) {
+	message := PushMessage{To: []ExponentPushToken{"ExponentPushToken[test]"}, InterruptionLevel: "time-sensitive"}
+	encoded := marshalMessage(t, message)
+	if !strings.Contains(encoded, `"interruptionLevel":"time-sensitive"`) {
+		t.Errorf("expected interruptionLevel field, got %s", encoded)
+	}
+}
+
+func TestPushMessageJSONRichContent(t *testing.T) {
+	message := PushMessage{
+		To:          []ExponentPushToken{"ExponentPushToken[test]"},
+		RichContent: &RichContent{Image: "https://example.com/image.png"},
+	}
+	encoded := marshalMessage(t, message)
+	if !strings.Contains(encoded, `"richContent":{"image":"https://example.com/image.png"}`) {
+		t.Errorf("expected richContent field, got %s", encoded)
+	}
+}
+
+func TestPushMessageValidateWithinLimit(t *testing.T) {
+	message := PushMessage{To: []ExponentPushToken{"ExponentPushToken[test]"}, Body: "hello"}
+	if err := message.Validate(); err != nil {
+		t.Errorf("expected small message to validate, got %s", err)
+	}
+}
+
+func TestPushMessageValidateTooBig(t *testing.T) {
+	message := PushMessage{
+		To:   []ExponentPushToken{"ExponentPushToken[test]"},
+		Data: map[string]interface{}{"blob": strings.Repeat("a", MaxMessageBytes)},
+	}
+	err := message.Validate()
+	if _, ok := err.(*MessageTooBigError); !ok {
+		t.Errorf("expected MessageTooBigError, got %T", err)
+	}
+}
+
 func TestValidateResponseErrorStatus(t *testing.T) {
 	response := &PushResponse{
 		Status:  "error",
@@ -62,6 +161,66 @@ func TestValidateResponseErrorMessageTooBig(t *testing.T) {
 	}
 }
 
+func TestValidateResponseErrorMismatchSenderID(t *testing.T) {
+	response := &PushResponse{
+		Status:  "error",
+		Message: "Mismatched sender ID",
+		Details: map[string]string{"error": "MismatchSenderId"},
+	}
+	err := response.ValidateResponse()
+	typed, ok := err.(*MismatchSenderIDError)
+	if !ok {
+		t.Error("Incorrect error type")
+	}
+	if typed.Response != response {
+		t.Error("Didn't return called response")
+	}
+}
+
+func TestValidateResponseErrorInvalidCredentials(t *testing.T) {
+	response := &PushResponse{
+		Status:  "error",
+		Message: "Invalid credentials",
+		Details: map[string]string{"error": "InvalidCredentials"},
+	}
+	err := response.ValidateResponse()
+	typed, ok := err.(*InvalidCredentialsError)
+	if !ok {
+		t.Error("Incorrect error type")
+	}
+	if typed.Response != response {
+		t.Error("Didn't return called response")
+	}
+}
+
+func TestNewPushServerErrorParsesErrorCode(t *testing.T) {
+	err := NewPushServerError("Invalid server response", nil, nil, []map[string]string{
+		{"code": ErrorTooManyRequests, "message": "Too many requests"},
+	})
+	if err.ErrorCode != ErrorTooManyRequests {
+		t.Errorf("Expected ErrorCode %q, got %q", ErrorTooManyRequests, err.ErrorCode)
+	}
+}
+
+func TestNewPushServerErrorNoErrors(t *testing.T) {
+	err := NewPushServerError("Invalid server response", nil, nil, nil)
+	if err.ErrorCode != "" {
+		t.Errorf("Expected empty ErrorCode, got %q", err.ErrorCode)
+	}
+}
+
+func TestValidateReceiptDeviceNotRegistered(t *testing.T) {
+	receipt := &PushReceipt{
+		Status:  "error",
+		Message: "Not registered",
+		Details: map[string]string{"error": "DeviceNotRegistered"},
+	}
+	err := receipt.ValidateResponse()
+	if _, ok := err.(*DeviceNotRegisteredError); !ok {
+		t.Error("Incorrect error type")
+	}
+}
+
 func TestValidateResponseErrorMessageRateExceeded(t *testing.T) {
 	response := &PushResponse{
 		Status:  "error",
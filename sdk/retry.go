@@ -0,0 +1,74 @@
+package expo
+
+import (
+	"context"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	// DefaultMaxRetries is the number of times a request is retried on a
+	// 429 or 5xx response before PushClient gives up
+	DefaultMaxRetries = 3
+	// baseRetryBackoff is the starting delay for the exponential backoff
+	// used when a response carries no Retry-After header
+	baseRetryBackoff = 1 * time.Second
+	// maxRetryBackoff caps the exponential backoff delay
+	maxRetryBackoff = 60 * time.Second
+)
+
+// isRetryableStatus reports whether an HTTP response should be retried,
+// following the same handling used by Expo's other provider SDKs: rate
+// limiting and server errors are transient, everything else isn't.
+func isRetryableStatus(statusCode int) bool {
+	return statusCode == http.StatusTooManyRequests || statusCode >= 500
+}
+
+// retryDelay returns how long to wait before retrying resp. It honours a
+// Retry-After header (seconds or an HTTP-date) when present, and otherwise
+// falls back to exponential backoff with full jitter, bounded by
+// maxRetryBackoff.
+func retryDelay(resp *http.Response, attempt int) time.Duration {
+	if d, ok := parseRetryAfter(resp.Header.Get("Retry-After")); ok {
+		return d
+	}
+	return backoffWithJitter(attempt)
+}
+
+func parseRetryAfter(value string) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if t, err := http.ParseTime(value); err == nil {
+		return time.Until(t), true
+	}
+	return 0, false
+}
+
+// backoffWithJitter implements exponential backoff with full jitter: base
+// 1s, factor 2, capped at maxRetryBackoff.
+func backoffWithJitter(attempt int) time.Duration {
+	backoff := baseRetryBackoff * time.Duration(1<<uint(attempt))
+	if backoff <= 0 || backoff > maxRetryBackoff {
+		backoff = maxRetryBackoff
+	}
+	return time.Duration(rand.Int63n(int64(backoff)))
+}
+
+// sleepContext waits for d, returning ctx.Err() early if ctx is cancelled
+// first.
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,35 @@
+package expo
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestIsRetryableStatus(t *testing.T) {
+	if !isRetryableStatus(http.StatusTooManyRequests) {
+		t.Error("429 should be retryable")
+	}
+	if !isRetryableStatus(http.StatusServiceUnavailable) {
+		t.Error("503 should be retryable")
+	}
+	if isRetryableStatus(http.StatusBadRequest) {
+		t.Error("400 should not be retryable")
+	}
+}
+
+func TestParseRetryAfterSeconds(t *testing.T) {
+	d, ok := parseRetryAfter("5")
+	if !ok {
+		t.Fatal("expected Retry-After to parse")
+	}
+	if d != 5*time.Second {
+		t.Errorf("expected 5s, got %s", d)
+	}
+}
+
+func TestParseRetryAfterMissing(t *testing.T) {
+	if _, ok := parseRetryAfter(""); ok {
+		t.Error("expected no Retry-After to be parsed from an empty header")
+	}
+}